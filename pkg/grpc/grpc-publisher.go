@@ -0,0 +1,376 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/golang/glog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+
+	"github.com/sbezverk/gobmp/pkg/bmp"
+	"github.com/sbezverk/gobmp/pkg/grpc/gobmppb"
+	"github.com/sbezverk/gobmp/pkg/kafka"
+	"github.com/sbezverk/gobmp/pkg/pub"
+)
+
+// subscriberQueueDepth bounds the number of Envelopes buffered for a slow
+// subscriber before PublishMessage starts dropping messages for it.
+const subscriberQueueDepth = 1024
+
+// messageTypes maps the bmp.*Msg constants onto their gobmppb.MessageType
+// counterpart.
+var messageTypes = map[int]gobmppb.MessageType{
+	bmp.PeerStateChangeMsg: gobmppb.MessageType_PEER_STATE_CHANGE,
+	bmp.UnicastPrefixMsg:   gobmppb.MessageType_UNICAST_PREFIX,
+	bmp.LSNodeMsg:          gobmppb.MessageType_LS_NODE,
+	bmp.LSLinkMsg:          gobmppb.MessageType_LS_LINK,
+	bmp.L3VPNMsg:           gobmppb.MessageType_L3VPN,
+	bmp.LSPrefixMsg:        gobmppb.MessageType_LS_PREFIX,
+	bmp.LSSRv6SIDMsg:       gobmppb.MessageType_LS_SRV6_SID,
+	bmp.EVPNMsg:            gobmppb.MessageType_EVPN,
+}
+
+// TLSConfig carries the TLS/mTLS material used to secure the gRPC listener.
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile, when set, requires and verifies a client certificate
+	// signed by this CA, enabling mutual TLS.
+	ClientCAFile string
+}
+
+// publisherConfig carries the settings applied by Option.
+type publisherConfig struct {
+	tls              *TLSConfig
+	keepalive        keepalive.ServerParameters
+	tracerProvider   trace.TracerProvider
+	compression      sarama.CompressionCodec
+	compressionLevel int
+}
+
+func defaultPublisherConfig() *publisherConfig {
+	return &publisherConfig{
+		keepalive: keepalive.ServerParameters{
+			Time:    2 * time.Minute,
+			Timeout: 20 * time.Second,
+		},
+		tracerProvider:   trace.NewNoopTracerProvider(),
+		compression:      sarama.CompressionNone,
+		compressionLevel: sarama.CompressionLevelDefault,
+	}
+}
+
+// Option configures the gRPC publisher used by NewGRPCPublisher.
+type Option func(*publisherConfig)
+
+// WithTLS enables TLS (optionally mutual TLS, via ClientCAFile) on the
+// gRPC listener.
+func WithTLS(cfg *TLSConfig) Option {
+	return func(c *publisherConfig) {
+		c.tls = cfg
+	}
+}
+
+// WithKeepalive overrides the gRPC server's keepalive parameters.
+func WithKeepalive(params keepalive.ServerParameters) Option {
+	return func(c *publisherConfig) {
+		c.keepalive = params
+	}
+}
+
+// WithTracerProvider instruments PublishMessage with spans obtained from tp
+// instead of the zero-overhead noop default.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *publisherConfig) {
+		c.tracerProvider = tp
+	}
+}
+
+// WithCompression compresses every Envelope payload with codec before it is
+// queued for subscribers, using the same kafka.CompressPayload codecs (and
+// encoder) the Kafka publisher uses. Subscribers read the codec back off
+// Envelope.Codec to decompress. Defaults to sarama.CompressionNone.
+func WithCompression(codec sarama.CompressionCodec, level int) Option {
+	return func(c *publisherConfig) {
+		c.compression = codec
+		c.compressionLevel = level
+	}
+}
+
+// subscriber is a single Subscribe() caller's queue and interest set.
+type subscriber struct {
+	types    map[gobmppb.MessageType]struct{}
+	filter   *gobmppb.Filter
+	encoding gobmppb.Encoding
+	ch       chan *gobmppb.Envelope
+	dropped  uint64
+}
+
+func (s *subscriber) wants(t gobmppb.MessageType) bool {
+	if len(s.types) == 0 {
+		return true
+	}
+	_, ok := s.types[t]
+	return ok
+}
+
+// matches reports whether a message's already-parsed JSON fields satisfy the
+// subscriber's filter. An empty filter matches everything; a non-zero field
+// only matches a payload that carries the same key with an equal value, so a
+// payload without that key does not match. Keys are those of pkg/message's
+// JSON marshalers (snake_case tags, e.g.
+// "peer_rd"/"router_ip" - not the bmp.*Msg Go field names). mt is needed
+// to derive an AFI/SAFI pair via deriveAfiSafi, since pkg/message never
+// encodes one directly.
+func (s *subscriber) matches(mt gobmppb.MessageType, fields map[string]interface{}) bool {
+	if s.filter == nil {
+		return true
+	}
+	if s.filter.PeerRd == "" && s.filter.RouterIp == "" && s.filter.Afi == 0 && s.filter.Safi == 0 {
+		return true
+	}
+	if fields == nil {
+		// Can't introspect the payload, so don't silently drop it.
+		return true
+	}
+	if s.filter.PeerRd != "" {
+		if v, ok := fields["peer_rd"].(string); !ok || v != s.filter.PeerRd {
+			return false
+		}
+	}
+	if s.filter.RouterIp != "" {
+		if v, ok := fields["router_ip"].(string); !ok || v != s.filter.RouterIp {
+			return false
+		}
+	}
+	if s.filter.Afi != 0 || s.filter.Safi != 0 {
+		afi, safi, ok := deriveAfiSafi(mt, fields)
+		if !ok {
+			// mt has no address family at all, so an AFI/SAFI filter can
+			// never be satisfied by it.
+			return false
+		}
+		if s.filter.Afi != 0 && afi != s.filter.Afi {
+			return false
+		}
+		if s.filter.Safi != 0 && safi != s.filter.Safi {
+			return false
+		}
+	}
+	return true
+}
+
+type publisher struct {
+	gobmppb.UnimplementedBMPPublisherServer
+
+	grpcSrv  *grpc.Server
+	listener net.Listener
+	tracer   trace.Tracer
+
+	compression      sarama.CompressionCodec
+	compressionLevel int
+
+	mu          sync.RWMutex
+	subscribers map[uint64]*subscriber
+	nextID      uint64
+}
+
+// Subscribe implements gobmppb.BMPPublisherServer. It registers the caller
+// as a subscriber for the lifetime of the stream and forwards every
+// Envelope published while it is connected.
+func (p *publisher) Subscribe(req *gobmppb.SubscribeRequest, stream gobmppb.BMPPublisher_SubscribeServer) error {
+	sub := &subscriber{
+		types:    make(map[gobmppb.MessageType]struct{}, len(req.Types)),
+		filter:   req.Filter,
+		encoding: req.Encoding,
+		ch:       make(chan *gobmppb.Envelope, subscriberQueueDepth),
+	}
+	for _, t := range req.Types {
+		sub.types[t] = struct{}{}
+	}
+
+	p.mu.Lock()
+	id := p.nextID
+	p.nextID++
+	p.subscribers[id] = sub
+	p.mu.Unlock()
+
+	glog.V(5).Infof("grpc publisher: subscriber %d connected, types: %v", id, req.Types)
+	defer func() {
+		p.mu.Lock()
+		delete(p.subscribers, id)
+		p.mu.Unlock()
+		glog.V(5).Infof("grpc publisher: subscriber %d disconnected, %d messages dropped", id, sub.dropped)
+	}()
+
+	for {
+		select {
+		case env := <-sub.ch:
+			if err := stream.Send(env); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// PublishMessage implements pub.Publisher. It fans the message out to every
+// subscriber whose type filter and payload filter match; a subscriber whose
+// queue is full has the message dropped for it rather than blocking the
+// parsing pipeline.
+func (p *publisher) PublishMessage(t int, key []byte, msg []byte) error {
+	mt, ok := messageTypes[t]
+	if !ok {
+		return fmt.Errorf("not implemented")
+	}
+
+	_, span := p.tracer.Start(context.Background(), "grpc.publish", trace.WithAttributes(
+		attribute.Int("bmp.message.type", t),
+		attribute.Int("bmp.message.bytes", len(msg)),
+	))
+	defer span.End()
+
+	var fields map[string]interface{}
+	_ = json.Unmarshal(msg, &fields)
+
+	jsonPayload := msg
+	jsonCodec := sarama.CompressionNone
+	if p.compression != sarama.CompressionNone {
+		if compressed, err := kafka.CompressPayload(p.compression, p.compressionLevel, msg); err != nil {
+			glog.Errorf("grpc publisher: failed to compress payload for message type %s, sending uncompressed: %+v", mt, err)
+		} else {
+			jsonPayload = compressed
+			jsonCodec = p.compression
+		}
+	}
+	jsonEnv := &gobmppb.Envelope{Type: mt, Key: key, Payload: jsonPayload, Encoding: gobmppb.Encoding_JSON, Codec: uint32(jsonCodec)}
+	var protobufEnv *gobmppb.Envelope
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	delivered := 0
+	for _, sub := range p.subscribers {
+		if !sub.wants(mt) || !sub.matches(mt, fields) {
+			continue
+		}
+
+		env := jsonEnv
+		if sub.encoding == gobmppb.Encoding_PROTOBUF {
+			if protobufEnv == nil {
+				payload, err := marshalProtobuf(mt, fields)
+				if err != nil {
+					glog.Errorf("grpc publisher: falling back to JSON encoding for message type %s: %+v", mt, err)
+					protobufEnv = jsonEnv
+				} else {
+					protobufCodec := sarama.CompressionNone
+					if p.compression != sarama.CompressionNone {
+						if compressed, err := kafka.CompressPayload(p.compression, p.compressionLevel, payload); err != nil {
+							glog.Errorf("grpc publisher: failed to compress protobuf payload for message type %s, sending uncompressed: %+v", mt, err)
+						} else {
+							payload = compressed
+							protobufCodec = p.compression
+						}
+					}
+					protobufEnv = &gobmppb.Envelope{Type: mt, Key: key, Payload: payload, Encoding: gobmppb.Encoding_PROTOBUF, Codec: uint32(protobufCodec)}
+				}
+			}
+			env = protobufEnv
+		}
+
+		select {
+		case sub.ch <- env:
+			delivered++
+		default:
+			atomic.AddUint64(&sub.dropped, 1)
+		}
+	}
+	span.SetAttributes(attribute.Int("grpc.subscribers.delivered", delivered))
+
+	return nil
+}
+
+func (p *publisher) Stop() {
+	p.grpcSrv.GracefulStop()
+}
+
+// NewGRPCPublisher starts a gRPC server on addr exposing the BMPPublisher
+// streaming service, returning a pub.Publisher that fans published messages
+// out to every connected subscriber.
+func NewGRPCPublisher(addr string, opts ...Option) (pub.Publisher, error) {
+	pc := defaultPublisherConfig()
+	for _, opt := range opts {
+		opt(pc)
+	}
+
+	srvOpts := []grpc.ServerOption{
+		grpc.KeepaliveParams(pc.keepalive),
+	}
+	if pc.tls != nil {
+		creds, err := buildTLSCredentials(pc.tls)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build TLS credentials: %w", err)
+		}
+		srvOpts = append(srvOpts, grpc.Creds(creds))
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	p := &publisher{
+		grpcSrv:          grpc.NewServer(srvOpts...),
+		listener:         listener,
+		subscribers:      make(map[uint64]*subscriber),
+		tracer:           pc.tracerProvider.Tracer("github.com/sbezverk/gobmp/pkg/grpc"),
+		compression:      pc.compression,
+		compressionLevel: pc.compressionLevel,
+	}
+	gobmppb.RegisterBMPPublisherServer(p.grpcSrv, p)
+
+	go func() {
+		if err := p.grpcSrv.Serve(listener); err != nil {
+			glog.Errorf("grpc publisher: server stopped with error: %+v", err)
+		}
+	}()
+	glog.Infof("grpc publisher: listening on %s", addr)
+
+	return p, nil
+}
+
+func buildTLSCredentials(cfg *TLSConfig) (credentials.TransportCredentials, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+	}
+	if cfg.ClientCAFile != "" {
+		ca, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("failed to parse client CA file %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
@@ -0,0 +1,173 @@
+package grpc
+
+import (
+	"fmt"
+
+	proto "github.com/golang/protobuf/proto"
+
+	"github.com/sbezverk/gobmp/pkg/grpc/gobmppb"
+)
+
+// marshalProtobuf converts fields, the generic JSON view of a published BMP
+// message already parsed by PublishMessage, into the gobmppb message that
+// mirrors mt and returns its protobuf wire encoding. fields uses pkg/message's
+// actual JSON tags (snake_case, "action": "add"/"del" rather than a bool,
+// "is_ipv4" rather than an explicit afi/safi pair) rather than the Go field
+// names of the bmp.*Msg types, since that's what's already on the wire by
+// the time PublishMessage receives msg. It is the realization of the
+// per-type .proto schemas in pkg/grpc/proto: every field a subscriber can
+// see over Encoding_PROTOBUF is read from fields here, so a schema that
+// gains a field but isn't wired in below will silently stop populating it.
+func marshalProtobuf(mt gobmppb.MessageType, fields map[string]interface{}) ([]byte, error) {
+	var m proto.Message
+	switch mt {
+	case gobmppb.MessageType_PEER_STATE_CHANGE:
+		m = &gobmppb.PeerStateChange{
+			RouterIp:    stringField(fields, "router_ip"),
+			PeerRd:      stringField(fields, "peer_rd"),
+			RemoteAsn:   stringField(fields, "remote_asn"),
+			RemoteBgpId: stringField(fields, "remote_bgp_id"),
+			RemoteIp:    stringField(fields, "remote_ip"),
+			IsIpv4:      boolField(fields, "is_ipv4"),
+			IsL3Vpn:     boolField(fields, "is_l3vpn"),
+			IsUp:        boolField(fields, "is_up"),
+		}
+	case gobmppb.MessageType_UNICAST_PREFIX:
+		afi, safi, _ := deriveAfiSafi(mt, fields)
+		m = &gobmppb.UnicastPrefix{
+			RouterIp:   stringField(fields, "router_ip"),
+			PeerRd:     stringField(fields, "peer_rd"),
+			Prefix:     stringField(fields, "prefix"),
+			PrefixLen:  uint32Field(fields, "prefix_len"),
+			Afi:        afi,
+			Safi:       safi,
+			IsWithdraw: isWithdraw(fields),
+		}
+	case gobmppb.MessageType_LS_NODE:
+		m = &gobmppb.LSNode{
+			RouterIp:    stringField(fields, "router_ip"),
+			IgpRouterId: stringField(fields, "igp_router_id"),
+			Asn:         uint32Field(fields, "asn"),
+			AreaId:      stringField(fields, "area_id"),
+			ProtocolId:  uint32Field(fields, "protocol_id"),
+			DomainId:    uint64Field(fields, "domain_id"),
+			IsWithdraw:  isWithdraw(fields),
+		}
+	case gobmppb.MessageType_LS_LINK:
+		m = &gobmppb.LSLink{
+			RouterIp:          stringField(fields, "router_ip"),
+			LocalIgpRouterId:  stringField(fields, "local_igp_router_id"),
+			RemoteIgpRouterId: stringField(fields, "remote_igp_router_id"),
+			LocalLinkIp:       stringField(fields, "local_link_ip"),
+			RemoteLinkIp:      stringField(fields, "remote_link_ip"),
+			LocalLinkId:       uint32Field(fields, "local_link_id"),
+			RemoteLinkId:      uint32Field(fields, "remote_link_id"),
+			IsWithdraw:        isWithdraw(fields),
+		}
+	case gobmppb.MessageType_L3VPN:
+		m = &gobmppb.L3VPN{
+			RouterIp:   stringField(fields, "router_ip"),
+			PeerRd:     stringField(fields, "peer_rd"),
+			VpnRd:      stringField(fields, "vpn_rd"),
+			Prefix:     stringField(fields, "prefix"),
+			PrefixLen:  uint32Field(fields, "prefix_len"),
+			Labels:     uint32SliceField(fields, "labels"),
+			IsWithdraw: isWithdraw(fields),
+		}
+	case gobmppb.MessageType_LS_PREFIX:
+		m = &gobmppb.LSPrefix{
+			RouterIp:    stringField(fields, "router_ip"),
+			IgpRouterId: stringField(fields, "igp_router_id"),
+			Prefix:      stringField(fields, "prefix"),
+			PrefixLen:   uint32Field(fields, "prefix_len"),
+			ProtocolId:  uint32Field(fields, "protocol_id"),
+			IsWithdraw:  isWithdraw(fields),
+		}
+	case gobmppb.MessageType_LS_SRV6_SID:
+		m = &gobmppb.LSSRv6SID{
+			RouterIp:    stringField(fields, "router_ip"),
+			IgpRouterId: stringField(fields, "igp_router_id"),
+			Srv6Sid:     stringField(fields, "srv6_sid"),
+			PrefixLen:   uint32Field(fields, "prefix_len"),
+			IsWithdraw:  isWithdraw(fields),
+		}
+	case gobmppb.MessageType_EVPN:
+		m = &gobmppb.EVPN{
+			RouterIp:   stringField(fields, "router_ip"),
+			PeerRd:     stringField(fields, "peer_rd"),
+			RouteType:  uint32Field(fields, "route_type"),
+			Esi:        stringField(fields, "esi"),
+			MacAddress: stringField(fields, "mac_address"),
+			IpAddress:  stringField(fields, "ip_address"),
+			Labels:     uint32SliceField(fields, "labels"),
+			IsWithdraw: isWithdraw(fields),
+		}
+	default:
+		return nil, fmt.Errorf("no protobuf schema registered for message type %s", mt)
+	}
+
+	return proto.Marshal(m)
+}
+
+// isWithdraw reports whether fields carries pkg/message's "action" marker
+// for a withdrawn route ("del"); any other value, including a missing
+// field, is treated as an add.
+func isWithdraw(fields map[string]interface{}) bool {
+	return stringField(fields, "action") == "del"
+}
+
+// deriveAfiSafi returns the IANA AFI/SAFI a subscriber's Filter can match
+// against for mt, since pkg/message never carries an explicit afi/safi pair
+// itself - only "is_ipv4" on the address-family-bearing message types. ok is
+// false for message types with no address family at all (PeerStateChange and
+// the link-state topology messages), meaning an AFI/SAFI filter can't be
+// evaluated against them.
+func deriveAfiSafi(mt gobmppb.MessageType, fields map[string]interface{}) (afi, safi uint32, ok bool) {
+	switch mt {
+	case gobmppb.MessageType_UNICAST_PREFIX:
+		safi = 1 // SAFI_UNICAST
+	case gobmppb.MessageType_L3VPN:
+		safi = 128 // SAFI_MPLS_LABELED_VPN
+	case gobmppb.MessageType_EVPN:
+		return 25, 70, true // AFI_L2VPN, SAFI_EVPN; no is_ipv4 to read
+	default:
+		return 0, 0, false
+	}
+	if boolField(fields, "is_ipv4") {
+		return 1, safi, true // AFI_IPv4
+	}
+	return 2, safi, true // AFI_IPv6
+}
+
+func stringField(fields map[string]interface{}, key string) string {
+	v, _ := fields[key].(string)
+	return v
+}
+
+func boolField(fields map[string]interface{}, key string) bool {
+	v, _ := fields[key].(bool)
+	return v
+}
+
+func uint32Field(fields map[string]interface{}, key string) uint32 {
+	v, _ := fields[key].(float64)
+	return uint32(v)
+}
+
+func uint64Field(fields map[string]interface{}, key string) uint64 {
+	v, _ := fields[key].(float64)
+	return uint64(v)
+}
+
+func uint32SliceField(fields map[string]interface{}, key string) []uint32 {
+	raw, _ := fields[key].([]interface{})
+	if len(raw) == 0 {
+		return nil
+	}
+	out := make([]uint32, 0, len(raw))
+	for _, v := range raw {
+		f, _ := v.(float64)
+		out = append(out, uint32(f))
+	}
+	return out
+}
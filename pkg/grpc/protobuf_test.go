@@ -0,0 +1,100 @@
+package grpc
+
+import (
+	"encoding/json"
+	"testing"
+
+	proto "github.com/golang/protobuf/proto"
+
+	"github.com/sbezverk/gobmp/pkg/grpc/gobmppb"
+)
+
+// unicastPrefixJSON mirrors the snake_case tags pkg/message's UnicastPrefix
+// actually marshals with, not the bmp.UnicastPrefixMsg Go field names.
+const unicastPrefixJSON = `{"router_ip":"10.0.0.1","peer_rd":"65000:100","prefix":"10.1.0.0","prefix_len":24,"is_ipv4":true,"action":"add"}`
+
+func unmarshalFields(t *testing.T, raw string) map[string]interface{} {
+	t.Helper()
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+		t.Fatalf("failed to unmarshal test payload: %+v", err)
+	}
+	return fields
+}
+
+func TestSubscriberMatchesRealFieldNames(t *testing.T) {
+	fields := unmarshalFields(t, unicastPrefixJSON)
+
+	tests := []struct {
+		name   string
+		filter *gobmppb.Filter
+		want   bool
+	}{
+		{"no filter", nil, true},
+		{"matching peer_rd and router_ip", &gobmppb.Filter{PeerRd: "65000:100", RouterIp: "10.0.0.1"}, true},
+		{"mismatched peer_rd", &gobmppb.Filter{PeerRd: "65000:200"}, false},
+		{"matching afi/safi (ipv4 unicast)", &gobmppb.Filter{Afi: 1, Safi: 1}, true},
+		{"mismatched afi (payload is ipv4, filter wants ipv6)", &gobmppb.Filter{Afi: 2}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := &subscriber{filter: tt.filter}
+			if got := sub.matches(gobmppb.MessageType_UNICAST_PREFIX, fields); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscriberMatchesAfiSafiOnAddressFamilyLessMessage(t *testing.T) {
+	fields := unmarshalFields(t, `{"router_ip":"10.0.0.1","igp_router_id":"0000.0000.0001","asn":65000,"area_id":"0.0.0.0","protocol_id":2,"domain_id":1,"action":"add"}`)
+
+	sub := &subscriber{filter: &gobmppb.Filter{Afi: 1}}
+	if sub.matches(gobmppb.MessageType_LS_NODE, fields) {
+		t.Error("matches() = true for an AFI filter against a message type with no address family, want false")
+	}
+}
+
+func TestMarshalProtobufRoundTrip(t *testing.T) {
+	fields := unmarshalFields(t, unicastPrefixJSON)
+
+	payload, err := marshalProtobuf(gobmppb.MessageType_UNICAST_PREFIX, fields)
+	if err != nil {
+		t.Fatalf("marshalProtobuf failed: %+v", err)
+	}
+
+	var got gobmppb.UnicastPrefix
+	if err := proto.Unmarshal(payload, &got); err != nil {
+		t.Fatalf("proto.Unmarshal failed: %+v", err)
+	}
+
+	want := gobmppb.UnicastPrefix{
+		RouterIp:   "10.0.0.1",
+		PeerRd:     "65000:100",
+		Prefix:     "10.1.0.0",
+		PrefixLen:  24,
+		Afi:        1,
+		Safi:       1,
+		IsWithdraw: false,
+	}
+	if got != want {
+		t.Errorf("marshalProtobuf round-trip = %+v, want %+v", got, want)
+	}
+}
+
+func TestMarshalProtobufWithdraw(t *testing.T) {
+	fields := unmarshalFields(t, `{"router_ip":"10.0.0.1","peer_rd":"65000:100","prefix":"10.1.0.0","prefix_len":24,"is_ipv4":true,"action":"del"}`)
+
+	payload, err := marshalProtobuf(gobmppb.MessageType_UNICAST_PREFIX, fields)
+	if err != nil {
+		t.Fatalf("marshalProtobuf failed: %+v", err)
+	}
+
+	var got gobmppb.UnicastPrefix
+	if err := proto.Unmarshal(payload, &got); err != nil {
+		t.Fatalf("proto.Unmarshal failed: %+v", err)
+	}
+	if !got.IsWithdraw {
+		t.Error("IsWithdraw = false for action=del, want true")
+	}
+}
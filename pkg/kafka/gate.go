@@ -0,0 +1,44 @@
+package kafka
+
+import "sync"
+
+// backpressureGate bounds the number of unacknowledged in-flight messages.
+// acquire blocks once high in-flight messages are outstanding; it unblocks
+// once the in-flight count has drained back down to low. Having distinct
+// high/low watermarks avoids a producer that is exactly at the limit
+// thrashing between blocked and unblocked on every single acknowledgement.
+type backpressureGate struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	inFlight int
+	high     int
+	low      int
+}
+
+func newBackpressureGate(high, low int) *backpressureGate {
+	g := &backpressureGate{high: high, low: low}
+	g.cond = sync.NewCond(&g.mu)
+	return g
+}
+
+// acquire blocks until fewer than g.high messages are in flight, then
+// reserves a slot for the caller.
+func (g *backpressureGate) acquire() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for g.inFlight >= g.high {
+		g.cond.Wait()
+	}
+	g.inFlight++
+}
+
+// release frees the caller's slot, waking any producer blocked in acquire
+// once the in-flight count has drained to g.low.
+func (g *backpressureGate) release() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.inFlight--
+	if g.inFlight <= g.low {
+		g.cond.Broadcast()
+	}
+}
@@ -1,15 +1,32 @@
 package kafka
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"math"
 	"net"
+	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Shopify/sarama"
 	"github.com/golang/glog"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/xdg-go/scram"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/sbezverk/gobmp/pkg/bmp"
+	"github.com/sbezverk/gobmp/pkg/metrics"
 	"github.com/sbezverk/gobmp/pkg/pub"
 )
 
@@ -26,13 +43,35 @@ const (
 )
 
 var (
-	brockerConnectTimeout = 10 * time.Second
-	topicCreateTimeout    = 1 * time.Second
+	topicCreateTimeout = 1 * time.Second
 )
 
+// TopicConfig describes how a single topic should be created, allowing
+// operators to override the defaults gobmp ships with (a single partition,
+// replication factor of 1 and infinite retention are unusable in a
+// production cluster).
+type TopicConfig struct {
+	NumPartitions     int32
+	ReplicationFactor int16
+	RetentionMs       string
+	CleanupPolicy     string
+}
+
+// defaultTopicConfig returns the topic settings gobmp has always used,
+// preserved here as the default when a topic has no override.
+func defaultTopicConfig() *TopicConfig {
+	return &TopicConfig{
+		NumPartitions:     1,
+		ReplicationFactor: 1,
+		RetentionMs:       "0",
+		CleanupPolicy:     "delete",
+	}
+}
+
 var (
-	// topics defines a list of topic to initialize and connect,
-	// initialization is done as a part of NewKafkaPublisher func.
+	// topicNames lists the logical topic names used to key per-topic
+	// overrides; both the published name and the TopicConfig can be
+	// overridden via WithTopics.
 	topicNames = []string{
 		peerTopic,
 		unicastMessageTopic,
@@ -46,110 +85,766 @@ var (
 )
 
 type publisher struct {
-	broker   *sarama.Broker
+	client   sarama.Client
+	admin    sarama.ClusterAdmin
 	config   *sarama.Config
 	producer sarama.AsyncProducer
+	topics   map[string]string
+	tracer   trace.Tracer
+	gate     *backpressureGate
+	wal      *wal
 	stopCh   chan struct{}
 }
 
+// DeliveryMode selects how aggressively NewKafkaPublisher guards against
+// message loss when the brokers are unavailable or slow.
+type DeliveryMode int
+
+const (
+	// DeliveryModeFireAndForget is the default: produceMessage queues a
+	// message and returns immediately, and a failed produce is only
+	// logged by the background goroutine draining producer.Errors().
+	DeliveryModeFireAndForget DeliveryMode = iota
+	// DeliveryModeReliable makes the producer idempotent and ack-all,
+	// applies backpressure in produceMessage once Reliable's in-flight
+	// watermark is reached, and buffers failed messages to an on-disk WAL
+	// that is replayed with exponential backoff once the broker recovers.
+	DeliveryModeReliable
+)
+
+// ReliableConfig configures DeliveryModeReliable. It is ignored unless
+// ProducerConfig.DeliveryMode is DeliveryModeReliable.
+type ReliableConfig struct {
+	// FlightHighWatermark is the number of unacknowledged in-flight
+	// messages at which produceMessage starts blocking.
+	FlightHighWatermark int
+	// FlightLowWatermark is the number of unacknowledged in-flight
+	// messages at or below which a blocked produceMessage resumes.
+	FlightLowWatermark int
+	// WALDir is the directory failed messages are buffered to.
+	WALDir string
+	// WALMaxEntries bounds how many pending messages a topic's WAL
+	// segment may hold before the oldest entry is dropped.
+	WALMaxEntries int
+	// InitialBackoff and MaxBackoff bound the exponential backoff between
+	// WAL replay attempts.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+func defaultReliableConfig() ReliableConfig {
+	return ReliableConfig{
+		FlightHighWatermark: 1000,
+		FlightLowWatermark:  500,
+		WALDir:              "/var/lib/gobmp/wal",
+		WALMaxEntries:       10000,
+		InitialBackoff:      500 * time.Millisecond,
+		MaxBackoff:          30 * time.Second,
+	}
+}
+
+// ProducerConfig carries the subset of Sarama async producer settings
+// operators need to tune when pushing high-volume BMP feeds (BGP-LS and
+// L3VPN updates in particular compress very well).
+type ProducerConfig struct {
+	// Compression selects the codec applied to every produced message.
+	// Defaults to sarama.CompressionNone.
+	Compression sarama.CompressionCodec
+	// CompressionLevel is codec specific, see sarama.CompressionLevelDefault.
+	CompressionLevel int
+	// FlushBytes, FlushMessages and FlushFrequency map directly onto
+	// sarama's Producer.Flush.* knobs and control how aggressively the
+	// producer batches messages before sending them to the broker.
+	FlushBytes     int
+	FlushMessages  int
+	FlushFrequency time.Duration
+	// MaxMessageBytes caps the size of a single produced message.
+	MaxMessageBytes int
+	// RequiredAcks controls how many replicas must ack a message before
+	// the producer considers it sent.
+	RequiredAcks sarama.RequiredAcks
+	// TLS enables and configures a TLS connection to the Kafka brokers.
+	TLS *TLSConfig
+	// SASL enables and configures SASL authentication to the Kafka brokers.
+	SASL *SASLConfig
+	// Kerberos enables and configures GSSAPI/Kerberos authentication.
+	Kerberos *KerberosConfig
+	// TopicNames overrides the published name of a topic, keyed by the
+	// logical topic name (one of the *Topic constants in this package).
+	TopicNames map[string]string
+	// Topics overrides the partition count, replication factor and
+	// retention/cleanup policy used when a topic is created, keyed by the
+	// logical topic name.
+	Topics map[string]*TopicConfig
+	// TracerProvider supplies the tracer used to instrument PublishMessage
+	// and produceMessage. Defaults to trace.NewNoopTracerProvider, making
+	// tracing zero-overhead unless explicitly configured.
+	TracerProvider trace.TracerProvider
+	// DeliveryMode selects between the default fire-and-forget behavior
+	// and DeliveryModeReliable's backpressure/WAL-backed retry behavior.
+	DeliveryMode DeliveryMode
+	// Reliable configures DeliveryModeReliable; ignored otherwise.
+	Reliable ReliableConfig
+}
+
+// TLSConfig carries the TLS material used to secure the connection to the
+// Kafka brokers.
+type TLSConfig struct {
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+}
+
+// SASLMechanism identifies the SASL mechanism used to authenticate to the
+// Kafka brokers.
+type SASLMechanism string
+
+// Supported SASL mechanisms.
+const (
+	SASLMechanismPlain       SASLMechanism = "PLAIN"
+	SASLMechanismSCRAMSHA256 SASLMechanism = "SCRAM-SHA-256"
+	SASLMechanismSCRAMSHA512 SASLMechanism = "SCRAM-SHA-512"
+	SASLMechanismOAuthBearer SASLMechanism = "OAUTHBEARER"
+)
+
+// SASLConfig carries SASL authentication settings for the Kafka connection.
+type SASLConfig struct {
+	Mechanism     SASLMechanism
+	User          string
+	Password      string
+	TokenProvider sarama.AccessTokenProvider
+}
+
+// KerberosConfig carries GSSAPI/Kerberos settings for brokers that require it.
+type KerberosConfig struct {
+	ServiceName     string
+	Realm           string
+	Username        string
+	Password        string
+	KeyTabPath      string
+	ConfigPath      string
+	DisablePAFXFAST bool
+}
+
+// DefaultProducerConfig returns the producer configuration used when the
+// caller does not supply any Option to NewKafkaPublisher.
+func DefaultProducerConfig() *ProducerConfig {
+	return &ProducerConfig{
+		Compression:      sarama.CompressionNone,
+		CompressionLevel: sarama.CompressionLevelDefault,
+		RequiredAcks:     sarama.WaitForLocal,
+		TracerProvider:   trace.NewNoopTracerProvider(),
+		DeliveryMode:     DeliveryModeFireAndForget,
+		Reliable:         defaultReliableConfig(),
+	}
+}
+
+// Option configures the Kafka producer used by NewKafkaPublisher.
+type Option func(*ProducerConfig)
+
+// WithCompression sets the compression codec and level applied to every
+// produced message.
+func WithCompression(codec sarama.CompressionCodec, level int) Option {
+	return func(c *ProducerConfig) {
+		c.Compression = codec
+		c.CompressionLevel = level
+	}
+}
+
+// WithFlush overrides the producer's batching behavior.
+func WithFlush(bytes, messages int, frequency time.Duration) Option {
+	return func(c *ProducerConfig) {
+		c.FlushBytes = bytes
+		c.FlushMessages = messages
+		c.FlushFrequency = frequency
+	}
+}
+
+// WithMaxMessageBytes caps the size of a single produced message.
+func WithMaxMessageBytes(n int) Option {
+	return func(c *ProducerConfig) {
+		c.MaxMessageBytes = n
+	}
+}
+
+// WithRequiredAcks overrides how many replicas must ack a message before
+// the producer considers it sent.
+func WithRequiredAcks(acks sarama.RequiredAcks) Option {
+	return func(c *ProducerConfig) {
+		c.RequiredAcks = acks
+	}
+}
+
+// WithTLS enables TLS on the connection to the Kafka brokers.
+func WithTLS(tls *TLSConfig) Option {
+	return func(c *ProducerConfig) {
+		c.TLS = tls
+	}
+}
+
+// WithSASL enables SASL authentication to the Kafka brokers.
+func WithSASL(sasl *SASLConfig) Option {
+	return func(c *ProducerConfig) {
+		c.SASL = sasl
+	}
+}
+
+// WithKerberos enables GSSAPI/Kerberos authentication to the Kafka brokers.
+func WithKerberos(krb5 *KerberosConfig) Option {
+	return func(c *ProducerConfig) {
+		c.Kerberos = krb5
+	}
+}
+
+// WithTracerProvider instruments PublishMessage and produceMessage with
+// spans obtained from tp instead of the zero-overhead noop default.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *ProducerConfig) {
+		c.TracerProvider = tp
+	}
+}
+
+// WithDeliveryMode switches the producer between the default
+// fire-and-forget behavior and DeliveryModeReliable's backpressure/WAL
+// retry behavior. cfg is ignored when mode is DeliveryModeFireAndForget.
+func WithDeliveryMode(mode DeliveryMode, cfg ReliableConfig) Option {
+	return func(c *ProducerConfig) {
+		c.DeliveryMode = mode
+		c.Reliable = cfg
+	}
+}
+
+// WithTopics overrides the published name and/or creation settings of the
+// topics gobmp manages, keyed by the logical topic name (one of the *Topic
+// constants in this package). It is intended to be populated from a
+// YAML/JSON topic configuration file.
+func WithTopics(names map[string]string, configs map[string]*TopicConfig) Option {
+	return func(c *ProducerConfig) {
+		c.TopicNames = names
+		c.Topics = configs
+	}
+}
+
 func (p *publisher) PublishMessage(t int, key []byte, msg []byte) error {
+	var topic string
 	switch t {
 	case bmp.PeerStateChangeMsg:
-		return p.produceMessage(peerTopic, key, msg)
+		topic = p.topics[peerTopic]
 	case bmp.UnicastPrefixMsg:
-		return p.produceMessage(unicastMessageTopic, key, msg)
+		topic = p.topics[unicastMessageTopic]
 	case bmp.LSNodeMsg:
-		return p.produceMessage(lsNodeMessageTopic, key, msg)
+		topic = p.topics[lsNodeMessageTopic]
 	case bmp.LSLinkMsg:
-		return p.produceMessage(lsLinkMessageTopic, key, msg)
+		topic = p.topics[lsLinkMessageTopic]
 	case bmp.L3VPNMsg:
-		return p.produceMessage(l3vpnMessageTopic, key, msg)
+		topic = p.topics[l3vpnMessageTopic]
 	case bmp.LSPrefixMsg:
-		return p.produceMessage(lsPrefixMessageTopic, key, msg)
+		topic = p.topics[lsPrefixMessageTopic]
 	case bmp.LSSRv6SIDMsg:
-		return p.produceMessage(lsSRv6SIDMessageTopic, key, msg)
+		topic = p.topics[lsSRv6SIDMessageTopic]
 	case bmp.EVPNMsg:
-		return p.produceMessage(evpnMessageTopic, key, msg)
+		topic = p.topics[evpnMessageTopic]
+	default:
+		return fmt.Errorf("not implemented")
 	}
 
-	return fmt.Errorf("not implemented")
+	ctx, span := p.tracer.Start(context.Background(), "kafka.publish", trace.WithAttributes(
+		attribute.Int("bmp.message.type", t),
+		attribute.Int("bmp.message.bytes", len(msg)),
+		attribute.String("kafka.topic", topic),
+	))
+	defer span.End()
+
+	if err := p.produceMessage(ctx, t, topic, key, msg); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	return nil
+}
+
+// produceMeta rides along as a produced message's Metadata so the
+// success/error draining goroutine can complete its span and record its
+// end-to-end publish latency once the broker acknowledges it. isReplay and
+// walSeq identify a message resent from the WAL by replayWAL, so
+// completeProduce knows to remove it from the WAL on success instead of
+// buffering it again on failure.
+type produceMeta struct {
+	span     trace.Span
+	topic    string
+	msgType  int
+	key      []byte
+	value    []byte
+	start    time.Time
+	isReplay bool
+	walSeq   uint64
+}
+
+func (p *publisher) produceMessage(ctx context.Context, t int, topic string, key []byte, msg []byte) error {
+	return p.produce(ctx, t, topic, key, msg, false, 0)
 }
 
-func (p *publisher) produceMessage(topic string, key []byte, msg []byte) error {
+// produceReplay resends a message previously buffered to the WAL under seq;
+// completeProduce removes it from the WAL once the broker acknowledges it.
+func (p *publisher) produceReplay(ctx context.Context, t int, topic string, key []byte, msg []byte, seq uint64) error {
+	return p.produce(ctx, t, topic, key, msg, true, seq)
+}
+
+func (p *publisher) produce(ctx context.Context, t int, topic string, key []byte, msg []byte, isReplay bool, walSeq uint64) error {
+	start := time.Now()
+	if p.gate != nil {
+		p.gate.acquire()
+	}
+
+	ctx, span := p.tracer.Start(ctx, "kafka.produce", trace.WithAttributes(attribute.String("kafka.topic", topic)))
+
 	k := sarama.ByteEncoder{}
 	k = key
 	m := sarama.ByteEncoder{}
 	m = msg
-	p.producer.Input() <- &sarama.ProducerMessage{
-		Topic: topic,
-		Key:   k,
-		Value: m,
+	pm := &sarama.ProducerMessage{
+		Topic:   topic,
+		Key:     k,
+		Value:   m,
+		Headers: injectTraceHeader(ctx),
+		Metadata: &produceMeta{
+			span:     span,
+			topic:    topic,
+			msgType:  t,
+			key:      key,
+			value:    msg,
+			start:    start,
+			isReplay: isReplay,
+			walSeq:   walSeq,
+		},
 	}
+	metrics.ProducerQueueDepth.WithLabelValues(topic).Inc()
+	p.producer.Input() <- pm
 
 	return nil
 }
 
+// injectTraceHeader propagates the current span's trace-id as a Kafka
+// record header so downstream consumers can correlate a message back to
+// the trace that produced it.
+func injectTraceHeader(ctx context.Context) []sarama.RecordHeader {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	headers := make([]sarama.RecordHeader, 0, len(carrier))
+	for k, v := range carrier {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(k), Value: []byte(v)})
+	}
+	return headers
+}
+
+// CompressPayload compresses msg with codec at the given level. It is
+// exported so other implementations of pub.Publisher (e.g. a gRPC
+// publisher) that don't have Sarama compress their payloads on the wire can
+// still share Kafka's codec selection instead of reimplementing it.
+func CompressPayload(codec sarama.CompressionCodec, level int, msg []byte) ([]byte, error) {
+	switch codec {
+	case sarama.CompressionNone:
+		return msg, nil
+	case sarama.CompressionGZIP:
+		var buf bytes.Buffer
+		w, err := gzip.NewWriterLevel(&buf, gzipLevel(level))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(msg); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case sarama.CompressionSnappy:
+		return snappy.Encode(nil, msg), nil
+	case sarama.CompressionLZ4:
+		var buf bytes.Buffer
+		w := lz4.NewWriter(&buf)
+		if level > 0 {
+			_ = w.Apply(lz4.CompressionLevelOption(lz4.CompressionLevel(level)))
+		}
+		if _, err := w.Write(msg); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case sarama.CompressionZSTD:
+		enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstdLevel(level)))
+		if err != nil {
+			return nil, err
+		}
+		defer enc.Close()
+		return enc.EncodeAll(msg, nil), nil
+	}
+
+	return nil, fmt.Errorf("unsupported compression codec: %d", codec)
+}
+
+func gzipLevel(level int) int {
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		return gzip.DefaultCompression
+	}
+	return level
+}
+
+func zstdLevel(level int) zstd.EncoderLevel {
+	if level <= 0 {
+		return zstd.SpeedDefault
+	}
+	return zstd.EncoderLevelFromZstd(level)
+}
+
 func (p *publisher) Stop() {
 	close(p.stopCh)
-	p.broker.Close()
+	p.admin.Close()
+	p.client.Close()
 }
 
-// NewKafkaPublisher instantiates a new instance of a Kafka publisher
-func NewKafkaPublisher(kafkaSrv string) (pub.Publisher, error) {
+// NewKafkaPublisher instantiates a new instance of a Kafka publisher.
+// kafkaSrv is a comma-separated list of "host:port" bootstrap brokers.
+func NewKafkaPublisher(kafkaSrv string, opts ...Option) (pub.Publisher, error) {
 	glog.Infof("Initializing Kafka producer client")
-	if err := validator(kafkaSrv); err != nil {
-		glog.Errorf("Failed to validate Kafka server address %s with error: %+v", kafkaSrv, err)
+	brokers := strings.Split(kafkaSrv, ",")
+	if err := validator(brokers); err != nil {
+		glog.Errorf("Failed to validate Kafka bootstrap list %s with error: %+v", kafkaSrv, err)
 		return nil, err
 	}
+	pc := DefaultProducerConfig()
+	for _, opt := range opts {
+		opt(pc)
+	}
+
 	config := sarama.NewConfig()
 	config.Producer.Return.Successes = true
 	config.Version = sarama.V2_5_0_0
-
-	br := sarama.NewBroker(kafkaSrv)
-	if err := br.Open(config); err != nil {
-		if err != sarama.ErrAlreadyConnected {
+	config.Producer.Compression = pc.Compression
+	config.Producer.CompressionLevel = pc.CompressionLevel
+	config.Producer.RequiredAcks = pc.RequiredAcks
+	if pc.MaxMessageBytes != 0 {
+		config.Producer.MaxMessageBytes = pc.MaxMessageBytes
+	}
+	if pc.FlushBytes != 0 {
+		config.Producer.Flush.Bytes = pc.FlushBytes
+	}
+	if pc.FlushMessages != 0 {
+		config.Producer.Flush.Messages = pc.FlushMessages
+	}
+	if pc.FlushFrequency != 0 {
+		config.Producer.Flush.Frequency = pc.FlushFrequency
+	}
+	var w *wal
+	var gate *backpressureGate
+	if pc.DeliveryMode == DeliveryModeReliable {
+		config.Producer.Idempotent = true
+		config.Producer.RequiredAcks = sarama.WaitForAll
+		config.Net.MaxOpenRequests = 1
+		gate = newBackpressureGate(pc.Reliable.FlightHighWatermark, pc.Reliable.FlightLowWatermark)
+		var err error
+		w, err = newWAL(pc.Reliable.WALDir, pc.Reliable.WALMaxEntries)
+		if err != nil {
 			return nil, err
 		}
 	}
-	if err := waitForBrokerConnection(br, brockerConnectTimeout); err != nil {
-		glog.Errorf("failed to open connection to the broker with error: %+v\n", err)
+	if err := applySecurity(config, pc); err != nil {
+		return nil, err
+	}
+
+	client, err := sarama.NewClient(brokers, config)
+	if err != nil {
+		glog.Errorf("failed to connect to the Kafka cluster %s with error: %+v", kafkaSrv, err)
+		return nil, err
+	}
+	glog.V(5).Infof("Connected to Kafka cluster: %s\n", kafkaSrv)
+
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		client.Close()
 		return nil, err
 	}
-	glog.V(5).Infof("Connected to broker: %s id: %d\n", br.Addr(), br.ID())
 
-	for _, t := range topicNames {
-		if err := ensureTopic(br, topicCreateTimeout, t); err != nil {
+	topics := resolveTopics(pc)
+	for logical, name := range topics {
+		if err := ensureTopic(admin, topicCreateTimeout, name, resolveTopicConfig(pc, logical)); err != nil {
+			admin.Close()
+			client.Close()
 			return nil, err
 		}
 	}
-	producer, err := sarama.NewAsyncProducer([]string{kafkaSrv}, config)
+	producer, err := sarama.NewAsyncProducerFromClient(client)
 	if err != nil {
+		admin.Close()
+		client.Close()
 		return nil, err
 	}
 	glog.V(5).Infof("Initialized Kafka Async producer")
-	stopCh := make(chan struct{})
+
+	p := &publisher{
+		stopCh:   make(chan struct{}),
+		client:   client,
+		admin:    admin,
+		config:   config,
+		producer: producer,
+		topics:   topics,
+		tracer:   pc.TracerProvider.Tracer("github.com/sbezverk/gobmp/pkg/kafka"),
+		gate:     gate,
+		wal:      w,
+	}
+
 	go func(producer sarama.AsyncProducer, stopCh <-chan struct{}) {
 		for {
 			select {
-			case <-producer.Successes():
+			case msg := <-producer.Successes():
+				p.completeProduce(msg, nil)
 			case err := <-producer.Errors():
 				glog.Errorf("failed to produce message with error: %+v", *err)
+				p.completeProduce(err.Msg, err)
 			case <-stopCh:
 				producer.Close()
 				return
 			}
 		}
-	}(producer, stopCh)
+	}(producer, p.stopCh)
 
-	return &publisher{
-		stopCh:   stopCh,
-		broker:   br,
-		config:   config,
-		producer: producer,
-	}, nil
+	if p.wal != nil {
+		go p.replayWAL(pc.Reliable.InitialBackoff, pc.Reliable.MaxBackoff)
+	}
+
+	return p, nil
+}
+
+// completeProduce finishes the span and metrics started by produceMessage
+// once the broker acknowledges (or fails to acknowledge) a message: it
+// records the partition/offset and publish latency on success, buffers the
+// message to the WAL on failure in DeliveryModeReliable (unless it was
+// already buffered, i.e. this was itself a replay), removes it from the WAL
+// on a successful replay, and always releases the queue depth gauge and any
+// backpressure gate slot.
+func (p *publisher) completeProduce(msg *sarama.ProducerMessage, produceErr error) {
+	if msg == nil {
+		return
+	}
+	meta, ok := msg.Metadata.(*produceMeta)
+	if !ok || meta == nil {
+		return
+	}
+	if p.gate != nil {
+		p.gate.release()
+	}
+
+	metrics.ProducerQueueDepth.WithLabelValues(meta.topic).Dec()
+	if produceErr != nil {
+		meta.span.RecordError(produceErr)
+		meta.span.SetStatus(codes.Error, produceErr.Error())
+		metrics.ProduceErrors.WithLabelValues(meta.topic).Inc()
+		if p.wal != nil && !meta.isReplay {
+			if _, err := p.wal.append(meta.topic, meta.msgType, meta.key, meta.value); err != nil {
+				glog.Errorf("failed to buffer message for topic %s to WAL: %+v", meta.topic, err)
+			}
+		}
+	} else {
+		meta.span.SetAttributes(
+			attribute.Int("kafka.partition", int(msg.Partition)),
+			attribute.Int64("kafka.offset", msg.Offset),
+		)
+		metrics.PublishLatency.WithLabelValues(meta.topic).Observe(time.Since(meta.start).Seconds())
+		metrics.MessagesPublished.WithLabelValues(meta.topic, strconv.Itoa(meta.msgType)).Inc()
+		if p.wal != nil && meta.isReplay {
+			if err := p.wal.remove(meta.topic, meta.walSeq); err != nil {
+				glog.Errorf("failed to remove replayed message for topic %s from WAL: %+v", meta.topic, err)
+			}
+		}
+	}
+	meta.span.End()
 }
 
-func validator(addr string) error {
+// replayWAL periodically resends every message buffered in the WAL. While
+// the WAL holds entries, the interval between attempts doubles after each
+// attempt, from initialBackoff up to maxBackoff; it resets to initialBackoff
+// once an attempt finds nothing to replay. An entry is only removed from the
+// WAL by completeProduce once its resend is acknowledged, so a crash mid-
+// replay (or a resend that itself fails) leaves it buffered rather than
+// lost. It returns once p.stopCh is closed.
+func (p *publisher) replayWAL(initialBackoff, maxBackoff time.Duration) {
+	backoff := initialBackoff
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-p.stopCh:
+			return
+		case <-timer.C:
+		}
+
+		replayed := false
+		for _, topic := range p.wal.topics() {
+			for _, e := range p.wal.peek(topic) {
+				if err := p.produceReplay(context.Background(), e.MsgType, topic, e.Key, e.Value, e.Seq); err != nil {
+					glog.Errorf("failed to replay buffered message for topic %s: %+v", topic, err)
+					continue
+				}
+				replayed = true
+			}
+		}
+
+		if !replayed {
+			backoff = initialBackoff
+		} else if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+		timer.Reset(backoff)
+	}
+}
+
+// scramClient adapts xdg-go/scram to sarama.SCRAMClient, the same pairing
+// sarama's own SCRAM examples use since it doesn't vendor a SCRAM
+// implementation itself.
+type scramClient struct {
+	mechanism scram.HashGeneratorFcn
+	conv      *scram.ClientConversation
+}
+
+func newSCRAMClient(mechanism scram.HashGeneratorFcn) *scramClient {
+	return &scramClient{mechanism: mechanism}
+}
+
+func (c *scramClient) Begin(userName, password, authzID string) error {
+	client, err := c.mechanism.NewClient(userName, password, authzID)
+	if err != nil {
+		return fmt.Errorf("failed to start SCRAM conversation: %w", err)
+	}
+	c.conv = client.NewConversation()
+	return nil
+}
+
+func (c *scramClient) Step(challenge string) (string, error) {
+	return c.conv.Step(challenge)
+}
+
+func (c *scramClient) Done() bool {
+	return c.conv.Done()
+}
+
+// applySecurity configures TLS, SASL and Kerberos on config from pc.
+func applySecurity(config *sarama.Config, pc *ProducerConfig) error {
+	if pc.TLS != nil {
+		tlsConfig, err := buildTLSConfig(pc.TLS)
+		if err != nil {
+			return fmt.Errorf("failed to build TLS config: %w", err)
+		}
+		config.Net.TLS.Enable = true
+		config.Net.TLS.Config = tlsConfig
+	}
+	if pc.SASL != nil {
+		config.Net.SASL.Enable = true
+		config.Net.SASL.User = pc.SASL.User
+		config.Net.SASL.Password = pc.SASL.Password
+		switch pc.SASL.Mechanism {
+		case SASLMechanismPlain, "":
+			config.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+		case SASLMechanismSCRAMSHA256:
+			config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+			config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return newSCRAMClient(scram.SHA256) }
+		case SASLMechanismSCRAMSHA512:
+			config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+			config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return newSCRAMClient(scram.SHA512) }
+		case SASLMechanismOAuthBearer:
+			config.Net.SASL.Mechanism = sarama.SASLTypeOAuth
+			config.Net.SASL.TokenProvider = pc.SASL.TokenProvider
+		default:
+			return fmt.Errorf("unsupported SASL mechanism: %s", pc.SASL.Mechanism)
+		}
+	}
+	if pc.Kerberos != nil {
+		config.Net.SASL.Enable = true
+		config.Net.SASL.Mechanism = sarama.SASLTypeGSSAPI
+		config.Net.SASL.GSSAPI.ServiceName = pc.Kerberos.ServiceName
+		config.Net.SASL.GSSAPI.Realm = pc.Kerberos.Realm
+		config.Net.SASL.GSSAPI.Username = pc.Kerberos.Username
+		config.Net.SASL.GSSAPI.Password = pc.Kerberos.Password
+		config.Net.SASL.GSSAPI.KeyTabPath = pc.Kerberos.KeyTabPath
+		config.Net.SASL.GSSAPI.KerberosConfigPath = pc.Kerberos.ConfigPath
+		config.Net.SASL.GSSAPI.DisablePAFXFAST = pc.Kerberos.DisablePAFXFAST
+		if pc.Kerberos.KeyTabPath != "" {
+			config.Net.SASL.GSSAPI.AuthType = sarama.KRB5_KEYTAB_AUTH
+		} else {
+			config.Net.SASL.GSSAPI.AuthType = sarama.KRB5_USER_AUTH
+		}
+	}
+	return nil
+}
+
+// buildTLSConfig turns a TLSConfig into a crypto/tls.Config, loading the CA
+// and client certificate material from disk.
+func buildTLSConfig(cfg *TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+	if cfg.CAFile != "" {
+		ca, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("failed to parse CA file %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// resolveTopics returns the logical to actual topic name mapping, applying
+// any overrides supplied via WithTopics.
+func resolveTopics(pc *ProducerConfig) map[string]string {
+	topics := make(map[string]string, len(topicNames))
+	for _, logical := range topicNames {
+		if name, ok := pc.TopicNames[logical]; ok && name != "" {
+			topics[logical] = name
+			continue
+		}
+		topics[logical] = logical
+	}
+	return topics
+}
+
+// resolveTopicConfig returns the TopicConfig to use for logical, falling
+// back to defaultTopicConfig when there is no override.
+func resolveTopicConfig(pc *ProducerConfig, logical string) *TopicConfig {
+	if tc, ok := pc.Topics[logical]; ok && tc != nil {
+		return tc
+	}
+	return defaultTopicConfig()
+}
+
+// validator checks that every address in brokers is a well-formed
+// "host:port" bootstrap address.
+func validator(brokers []string) error {
+	if len(brokers) == 0 {
+		return fmt.Errorf("bootstrap broker list cannot be empty")
+	}
+	for _, addr := range brokers {
+		if err := validateBroker(addr); err != nil {
+			return fmt.Errorf("invalid broker address %q: %w", addr, err)
+		}
+	}
+	return nil
+}
+
+func validateBroker(addr string) error {
 	host, port, _ := net.SplitHostPort(addr)
 	if host == "" || port == "" {
 		return fmt.Errorf("host or port cannot be ''")
@@ -171,62 +866,35 @@ func validator(addr string) error {
 	return nil
 }
 
-func ensureTopic(br *sarama.Broker, timeout time.Duration, topicName string) error {
+func ensureTopic(admin sarama.ClusterAdmin, timeout time.Duration, topicName string, tc *TopicConfig) error {
 	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
 	tout := time.NewTimer(timeout)
-	retention := "0"
-	topic := &sarama.CreateTopicsRequest{
-		TopicDetails: map[string]*sarama.TopicDetail{
-			topicName: {
-				NumPartitions:     1,
-				ReplicationFactor: 1,
-				ConfigEntries: map[string]*string{
-					"retention.ms":        &retention,
-					"delete.retention.ms": &retention,
-				},
-			},
+	defer tout.Stop()
+	retention := tc.RetentionMs
+	cleanupPolicy := tc.CleanupPolicy
+	detail := &sarama.TopicDetail{
+		NumPartitions:     tc.NumPartitions,
+		ReplicationFactor: tc.ReplicationFactor,
+		ConfigEntries: map[string]*string{
+			"retention.ms":   &retention,
+			"cleanup.policy": &cleanupPolicy,
 		},
 	}
 
 	for {
-		t, err := br.CreateTopics(topic)
-		if err != nil {
-			return err
-		}
-		if e, ok := t.TopicErrors[topicName]; ok {
-			if e.Err == sarama.ErrTopicAlreadyExists || e.Err == sarama.ErrNoError {
-				return nil
-			}
-			if e.Err != sarama.ErrRequestTimedOut {
-				return e
-			}
-		}
-		select {
-		case <-ticker.C:
-			continue
-		case <-tout.C:
-			return fmt.Errorf("timeout waiting for topic %s", topicName)
-		}
-	}
-}
-
-func waitForBrokerConnection(br *sarama.Broker, timeout time.Duration) error {
-	ticker := time.NewTicker(100 * time.Millisecond)
-	tout := time.NewTimer(timeout)
-	for {
-		ok, err := br.Connected()
-		if ok {
+		err := admin.CreateTopic(topicName, detail, false)
+		if err == nil || err == sarama.ErrTopicAlreadyExists {
 			return nil
 		}
-		if err != nil {
-			return err
+		if terr, ok := err.(*sarama.TopicError); ok && terr.Err != sarama.ErrRequestTimedOut {
+			return terr
 		}
 		select {
 		case <-ticker.C:
 			continue
 		case <-tout.C:
-			return fmt.Errorf("timeout waiting for the connection to the broker %s", br.Addr())
+			return fmt.Errorf("timeout waiting for topic %s: %w", topicName, err)
 		}
 	}
-
 }
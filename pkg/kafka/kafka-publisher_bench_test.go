@@ -0,0 +1,84 @@
+package kafka
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Shopify/sarama"
+)
+
+// bmpCorpus holds representative JSON payloads in the shape produceMessage
+// hands to Sarama for compression: published BMP messages with their
+// snake_case JSON keys (unicast prefix, L3VPN and LS node), repeated the way
+// a busy router's update stream repeats the same fields across many
+// prefixes/routes. A
+// synthetic all-zero buffer is a degenerate best case for every codec and
+// would tell us nothing about how snappy/gzip/lz4/zstd actually compare on
+// this traffic.
+var bmpCorpus = [][]byte{
+	repeatJSON(`{"router_ip":"10.0.0.1","peer_rd":"65000:100","prefix":"10.%[1]d.0.0","prefix_len":24,"is_ipv4":true,"action":"add"}`, 64),
+	repeatJSON(`{"router_ip":"10.0.0.1","peer_rd":"65000:100","vpn_rd":"65000:%[1]d","prefix":"192.168.%[1]d.0","prefix_len":24,"labels":[16000,%[1]d],"action":"add"}`, 256),
+	repeatJSON(`{"router_ip":"10.0.0.1","igp_router_id":"0000.0000.00%02[1]x","asn":65000,"area_id":"0.0.0.0","protocol_id":2,"domain_id":1,"action":"add"}`, 1024),
+}
+
+// repeatJSON renders n comma-separated copies of tmpl into a single JSON
+// array, approximating a batch of n consecutive BMP update messages sharing
+// most of their structure. tmpl is fed a single copy-index argument, fed to
+// every verb via an explicit argument index (e.g. %[1]d) so a verb repeated
+// within one copy doesn't consume extra positional args.
+func repeatJSON(tmpl string, n int) []byte {
+	buf := make([]byte, 0, n*(len(tmpl)+1)+2)
+	buf = append(buf, '[')
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, []byte(fmt.Sprintf(tmpl, i))...)
+	}
+	buf = append(buf, ']')
+	return buf
+}
+
+func benchmarkCompress(b *testing.B, codec sarama.CompressionCodec, level int) {
+	var originalBytes, compressedBytes int
+	for _, msg := range bmpCorpus {
+		out, err := CompressPayload(codec, level, msg)
+		if err != nil {
+			b.Fatalf("CompressPayload failed: %+v", err)
+		}
+		originalBytes += len(msg)
+		compressedBytes += len(out)
+	}
+	if compressedBytes > 0 {
+		b.ReportMetric(float64(originalBytes)/float64(compressedBytes), "ratio")
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, msg := range bmpCorpus {
+			if _, err := CompressPayload(codec, level, msg); err != nil {
+				b.Fatalf("CompressPayload failed: %+v", err)
+			}
+		}
+	}
+}
+
+func BenchmarkCompressNone(b *testing.B) {
+	benchmarkCompress(b, sarama.CompressionNone, sarama.CompressionLevelDefault)
+}
+
+func BenchmarkCompressSnappy(b *testing.B) {
+	benchmarkCompress(b, sarama.CompressionSnappy, sarama.CompressionLevelDefault)
+}
+
+func BenchmarkCompressGZIP(b *testing.B) {
+	benchmarkCompress(b, sarama.CompressionGZIP, sarama.CompressionLevelDefault)
+}
+
+func BenchmarkCompressLZ4(b *testing.B) {
+	benchmarkCompress(b, sarama.CompressionLZ4, sarama.CompressionLevelDefault)
+}
+
+func BenchmarkCompressZSTD(b *testing.B) {
+	benchmarkCompress(b, sarama.CompressionZSTD, sarama.CompressionLevelDefault)
+}
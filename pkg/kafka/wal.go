@@ -0,0 +1,204 @@
+package kafka
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/sbezverk/gobmp/pkg/metrics"
+)
+
+// walEntry is a single message buffered for replay after a failed produce.
+// Topic is carried inside the entry (rather than relied on from the segment
+// file name) so newWAL can recover it even after walSegmentName has hashed
+// the topic into an on-disk name.
+type walEntry struct {
+	Seq     uint64 `json:"seq"`
+	Topic   string `json:"topic"`
+	MsgType int    `json:"msgType"`
+	Key     []byte `json:"key"`
+	Value   []byte `json:"value"`
+}
+
+// wal is a bounded, on-disk write-ahead log for messages that failed to
+// publish, keyed by topic. Each topic's pending entries live in their own
+// segment file under dir so they survive a process restart. When a topic's
+// queue is already at maxEntries, the oldest entry is dropped to make room
+// for the newest — the WAL trades completeness for boundedness, consistent
+// with the at-least-once (not exactly-once) guarantee DeliveryModeReliable
+// documents. maxEntries <= 0 (a caller-supplied ReliableConfig that leaves
+// WALMaxEntries unset) disables that bound rather than panicking on an
+// empty queue. An entry is only removed once remove confirms it was
+// actually redelivered; replayWAL must never drain it up front.
+type wal struct {
+	dir        string
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string][]walEntry
+	nextSeq uint64
+}
+
+func newWAL(dir string, maxEntries int) (*wal, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create WAL directory %s: %w", dir, err)
+	}
+	w := &wal{
+		dir:        dir,
+		maxEntries: maxEntries,
+		entries:    make(map[string][]walEntry),
+	}
+
+	segments, err := filepath.Glob(filepath.Join(dir, "*.wal"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range segments {
+		entries, err := readWALSegment(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read WAL segment %s: %w", path, err)
+		}
+		for _, e := range entries {
+			w.entries[e.Topic] = append(w.entries[e.Topic], e)
+			if e.Seq >= w.nextSeq {
+				w.nextSeq = e.Seq + 1
+			}
+		}
+	}
+	for topic, entries := range w.entries {
+		metrics.WALDepth.WithLabelValues(topic).Set(float64(len(entries)))
+	}
+	return w, nil
+}
+
+func readWALSegment(path string) ([]walEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []walEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e walEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// append buffers key/value for topic, dropping the oldest pending entry
+// once the topic's queue is already at maxEntries, and returns the seq
+// assigned to the new entry so a caller can later remove it by seq.
+func (w *wal) append(topic string, msgType int, key, value []byte) (uint64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	entries := w.entries[topic]
+	if w.maxEntries > 0 && len(entries) >= w.maxEntries {
+		entries = entries[1:]
+	}
+	seq := w.nextSeq
+	w.nextSeq++
+	entries = append(entries, walEntry{Seq: seq, Topic: topic, MsgType: msgType, Key: key, Value: value})
+	w.entries[topic] = entries
+	metrics.WALDepth.WithLabelValues(topic).Set(float64(len(entries)))
+	return seq, w.flush(topic)
+}
+
+// peek returns a copy of every entry currently buffered for topic without
+// removing them; callers must resend and wait for acknowledgement before
+// calling remove, so a crash mid-replay leaves the entry buffered.
+func (w *wal) peek(topic string) []walEntry {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	entries := make([]walEntry, len(w.entries[topic]))
+	copy(entries, w.entries[topic])
+	return entries
+}
+
+// remove deletes the entry identified by (topic, seq) once its resend has
+// been acknowledged by the broker. It is a no-op if the entry is no longer
+// present (e.g. it was already removed by a previous replay attempt).
+func (w *wal) remove(topic string, seq uint64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	entries := w.entries[topic]
+	for i, e := range entries {
+		if e.Seq != seq {
+			continue
+		}
+		entries = append(entries[:i], entries[i+1:]...)
+		if len(entries) == 0 {
+			delete(w.entries, topic)
+		} else {
+			w.entries[topic] = entries
+		}
+		metrics.WALDepth.WithLabelValues(topic).Set(float64(len(entries)))
+		return w.flush(topic)
+	}
+	return nil
+}
+
+// topics returns the set of topics with at least one buffered entry.
+func (w *wal) topics() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	topics := make([]string, 0, len(w.entries))
+	for topic, entries := range w.entries {
+		if len(entries) > 0 {
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
+
+// flush rewrites topic's segment file from the in-memory queue. Callers
+// must hold w.mu.
+func (w *wal) flush(topic string) error {
+	f, err := os.Create(filepath.Join(w.dir, walSegmentName(topic)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	for _, e := range w.entries[topic] {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walSegmentName derives a filesystem-safe segment file name for topic:
+// every character outside [A-Za-z0-9_-] is replaced, and a short hash of
+// the original topic is appended so two topics that sanitize to the same
+// prefix (or a topic containing "/" or "..") can't collide or escape dir.
+// WithTopics (chunk0-2) lets an operator set arbitrary topic names, so this
+// can't assume topic is already a safe path component.
+func walSegmentName(topic string) string {
+	safe := make([]byte, len(topic))
+	for i := 0; i < len(topic); i++ {
+		c := topic[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_':
+			safe[i] = c
+		default:
+			safe[i] = '_'
+		}
+	}
+	sum := sha256.Sum256([]byte(topic))
+	return fmt.Sprintf("%s-%x.wal", safe, sum[:4])
+}
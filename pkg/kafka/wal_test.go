@@ -0,0 +1,56 @@
+package kafka
+
+import "testing"
+
+func TestWALAppendDropsOldestOnOverflow(t *testing.T) {
+	w, err := newWAL(t.TempDir(), 2)
+	if err != nil {
+		t.Fatalf("newWAL failed: %+v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := w.append("gobmp.parsed.unicast_prefix", 0, nil, []byte{byte(i)}); err != nil {
+			t.Fatalf("append failed: %+v", err)
+		}
+	}
+
+	entries := w.peek("gobmp.parsed.unicast_prefix")
+	if len(entries) != 2 {
+		t.Fatalf("len(entries) = %d, want 2", len(entries))
+	}
+	if entries[0].Value[0] != 1 || entries[1].Value[0] != 2 {
+		t.Errorf("entries = %+v, want the two most recently appended", entries)
+	}
+}
+
+// TestWALAppendZeroMaxEntries exercises the value ReliableConfig.WALMaxEntries
+// takes when a caller of WithDeliveryMode sets only some of ReliableConfig's
+// fields (e.g. just WALDir), leaving WALMaxEntries at its zero value rather
+// than defaultReliableConfig's 10000. append must not panic on an empty
+// queue in that case.
+func TestWALAppendZeroMaxEntries(t *testing.T) {
+	w, err := newWAL(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("newWAL failed: %+v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := w.append("gobmp.parsed.peer", 0, nil, []byte{byte(i)}); err != nil {
+			t.Fatalf("append failed: %+v", err)
+		}
+	}
+
+	if got := len(w.peek("gobmp.parsed.peer")); got != 3 {
+		t.Errorf("len(entries) = %d, want 3 (unbounded when maxEntries <= 0)", got)
+	}
+}
+
+func TestWALRemoveIsNoOpForMissingEntry(t *testing.T) {
+	w, err := newWAL(t.TempDir(), 10)
+	if err != nil {
+		t.Fatalf("newWAL failed: %+v", err)
+	}
+	if err := w.remove("gobmp.parsed.peer", 42); err != nil {
+		t.Errorf("remove of a never-buffered entry returned an error: %+v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Collectors exposed by the gobmp publisher and parsing pipeline.
+var (
+	// MessagesPublished counts messages successfully published, by topic
+	// and BMP message type.
+	MessagesPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gobmp_messages_published_total",
+		Help: "Total number of BMP messages published, by topic and message type.",
+	}, []string{"topic", "type"})
+
+	// ProduceErrors counts publish failures, by topic.
+	ProduceErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gobmp_produce_errors_total",
+		Help: "Total number of publish failures, by topic.",
+	}, []string{"topic"})
+
+	// ProducerQueueDepth tracks messages handed to the async producer that
+	// have not yet been acknowledged by the broker, by topic.
+	ProducerQueueDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gobmp_producer_queue_depth",
+		Help: "Number of messages queued in the async producer waiting to be acknowledged.",
+	}, []string{"topic"})
+
+	// PublishLatency measures the time between PublishMessage being
+	// called and the broker acknowledging the message. Failed messages
+	// are not observed.
+	PublishLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gobmp_publish_latency_seconds",
+		Help:    "Time between PublishMessage being called and the broker acknowledging the message.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"topic"})
+
+	// PeerMessages counts BMP messages received, by peer IP and message
+	// type. Incremented from the parsing pipeline.
+	PeerMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gobmp_peer_messages_total",
+		Help: "Total number of BMP messages received, by peer IP and message type.",
+	}, []string{"peer_ip", "type"})
+
+	// ParserErrors counts errors encountered while parsing a BMP message,
+	// by a short error reason. Incremented from the parsing pipeline.
+	ParserErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gobmp_parser_errors_total",
+		Help: "Total number of errors encountered by the parsing worker, by error reason.",
+	}, []string{"reason"})
+
+	// WALDepth tracks how many messages are buffered in the reliable
+	// delivery mode's on-disk WAL, by topic, waiting to be replayed.
+	WALDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gobmp_wal_depth",
+		Help: "Number of messages buffered in the reliable delivery WAL, by topic.",
+	}, []string{"topic"})
+)
+
+// Serve starts an HTTP server exposing /metrics on addr. It blocks until ctx
+// is canceled or the server fails to start, returning the startup error (if
+// any); ctx cancellation shuts the server down gracefully and returns nil.
+func Serve(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	srv := &http.Server{Addr: addr, Handler: mux}
+
+	errCh := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+			return
+		}
+		errCh <- nil
+	}()
+
+	select {
+	case <-ctx.Done():
+		glog.Infof("metrics: shutting down /metrics server on %s", addr)
+		return srv.Shutdown(context.Background())
+	case err := <-errCh:
+		return err
+	}
+}
@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMessagesPublished(t *testing.T) {
+	MessagesPublished.Reset()
+	MessagesPublished.WithLabelValues("gobmp.parsed.peer", "1").Inc()
+	MessagesPublished.WithLabelValues("gobmp.parsed.peer", "1").Inc()
+
+	got := testutil.ToFloat64(MessagesPublished.WithLabelValues("gobmp.parsed.peer", "1"))
+	if got != 2 {
+		t.Errorf("expected MessagesPublished to be 2, got %v", got)
+	}
+}
+
+func TestProduceErrors(t *testing.T) {
+	ProduceErrors.Reset()
+	ProduceErrors.WithLabelValues("gobmp.parsed.l3vpn").Inc()
+
+	got := testutil.ToFloat64(ProduceErrors.WithLabelValues("gobmp.parsed.l3vpn"))
+	if got != 1 {
+		t.Errorf("expected ProduceErrors to be 1, got %v", got)
+	}
+}
@@ -0,0 +1,21 @@
+package parser
+
+import (
+	"strconv"
+
+	"github.com/sbezverk/gobmp/pkg/metrics"
+)
+
+// recordPeerMessage increments the per-peer message counter for a BMP
+// message of type t received from peerIP. It is meant to be called from
+// parsingWorker once a message has been successfully decoded.
+func recordPeerMessage(peerIP string, t int) {
+	metrics.PeerMessages.WithLabelValues(peerIP, strconv.Itoa(t)).Inc()
+}
+
+// recordParserError increments the parser error counter for reason. It is
+// meant to be called from parsingWorker wherever it currently only logs a
+// decoding failure.
+func recordParserError(reason string) {
+	metrics.ParserErrors.WithLabelValues(reason).Inc()
+}
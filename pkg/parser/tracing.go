@@ -0,0 +1,31 @@
+package parser
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer is used to instrument parsingWorker. It defaults to a noop tracer
+// so parsing stays zero-overhead unless SetTracerProvider is called.
+var tracer trace.Tracer = trace.NewNoopTracerProvider().Tracer("github.com/sbezverk/gobmp/pkg/parser")
+
+// SetTracerProvider switches parsingWorker's instrumentation to spans
+// obtained from tp. Call it once during startup, before any BMP messages
+// are parsed.
+func SetTracerProvider(tp trace.TracerProvider) {
+	tracer = tp.Tracer("github.com/sbezverk/gobmp/pkg/parser")
+}
+
+// startParseSpan opens the span for parsing a raw BMP message. peerIP is the
+// address of the BMP-speaking router the message arrived from.
+// pub.Publisher.PublishMessage takes no context, and both publishers start
+// their spans from context.Background(), so publisher spans are not
+// children of this span.
+func startParseSpan(ctx context.Context, peerIP string, raw []byte) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "bmp.parse", trace.WithAttributes(
+		attribute.String("bmp.peer.ip", peerIP),
+		attribute.Int("bmp.message.bytes", len(raw)),
+	))
+}
@@ -0,0 +1,95 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/exporters/zipkin"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Exporter identifies which OpenTelemetry backend NewTracerProvider exports
+// spans to.
+type Exporter string
+
+// Supported exporters.
+const (
+	ExporterNone   Exporter = ""
+	ExporterOTLP   Exporter = "otlp"
+	ExporterJaeger Exporter = "jaeger"
+	ExporterZipkin Exporter = "zipkin"
+	ExporterStdout Exporter = "stdout"
+)
+
+// Config selects the exporter, endpoint and sampling behavior used by
+// NewTracerProvider.
+type Config struct {
+	Exporter    Exporter
+	Endpoint    string
+	ServiceName string
+	// SampleRatio is the fraction of traces recorded, in [0, 1]. A ratio
+	// of 0 never samples, a ratio >= 1 always samples.
+	SampleRatio float64
+}
+
+// NewTracerProvider builds a TracerProvider from cfg and a shutdown func to
+// flush and close it. When cfg.Exporter is ExporterNone, it returns
+// trace.NewNoopTracerProvider so that tracing is zero-overhead by default.
+func NewTracerProvider(ctx context.Context, cfg Config) (trace.TracerProvider, func(context.Context) error, error) {
+	if cfg.Exporter == ExporterNone {
+		return trace.NewNoopTracerProvider(), func(context.Context) error { return nil }, nil
+	}
+
+	exp, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build %s exporter: %w", cfg.Exporter, err)
+	}
+
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = "gobmp"
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceNameKey.String(serviceName)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sampler(cfg.SampleRatio)),
+	)
+
+	return tp, tp.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case ExporterOTLP:
+		return otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(cfg.Endpoint), otlptracegrpc.WithInsecure())
+	case ExporterJaeger:
+		return jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(cfg.Endpoint)))
+	case ExporterZipkin:
+		return zipkin.New(cfg.Endpoint)
+	case ExporterStdout:
+		return stdouttrace.New()
+	}
+	return nil, fmt.Errorf("unsupported exporter: %s", cfg.Exporter)
+}
+
+func sampler(ratio float64) sdktrace.Sampler {
+	switch {
+	case ratio <= 0:
+		return sdktrace.NeverSample()
+	case ratio >= 1:
+		return sdktrace.AlwaysSample()
+	default:
+		return sdktrace.TraceIDRatioBased(ratio)
+	}
+}